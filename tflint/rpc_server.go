@@ -0,0 +1,261 @@
+package tflint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/rpc"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// RunnerRPCServer exposes a Runner over net/rpc so that out-of-process rule
+// plugins can drive expression evaluation without being statically linked
+// into the TFLint binary. It is the server side for the RPC client the doc
+// comment on EvaluateExpr refers to.
+//
+// hcl.Expression and hcl.Block values can't be marshalled directly, so
+// callers identify one by the filename and hcl.Range it occupies in the
+// already-loaded config (see ExprRef/BlockRef); the server re-resolves that
+// range against its own parsed files before evaluating it. Values cross the
+// wire as cty/json, typed by the requested cty.Type.
+type RunnerRPCServer struct {
+	runner *Runner
+}
+
+// NewRunnerRPCServer wraps runner for use with net/rpc.
+func NewRunnerRPCServer(runner *Runner) *RunnerRPCServer {
+	return &RunnerRPCServer{runner: runner}
+}
+
+// Serve registers the server under the "Runner" service name and blocks,
+// serving requests over conn. A rule plugin run out-of-process connects to
+// this over stdio or a unix socket, the same transport model
+// hashicorp/go-plugin uses for its other plugin kinds.
+func (s *RunnerRPCServer) Serve(conn io.ReadWriteCloser) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Runner", s); err != nil {
+		return err
+	}
+	server.ServeConn(conn)
+	return nil
+}
+
+// ExprRef identifies an expression already present in the server's loaded
+// configuration by its source location, since hcl.Expression can't be
+// serialized across the wire.
+type ExprRef struct {
+	Filename string
+	Range    hcl.Range
+}
+
+// BlockRef identifies a block like ExprRef identifies an expression, except
+// that Range is the block's DefRange (its type and labels).
+type BlockRef struct {
+	Filename string
+	Range    hcl.Range
+}
+
+// EvaluateExprArgs is the net/rpc argument type for RunnerRPCServer.EvaluateExpr.
+type EvaluateExprArgs struct {
+	Expr     ExprRef
+	WantType []byte // cty.Type encoded with ctyjson.MarshalType
+}
+
+// EvaluateExprReply is the net/rpc reply type for RunnerRPCServer.EvaluateExpr.
+type EvaluateExprReply struct {
+	Value []byte // cty.Value encoded with ctyjson.Marshal, typed by WantType
+}
+
+// EvaluateExpr re-resolves the expression identified by args.Expr against
+// the server's own parsed files and evaluates it with Runner.EvalExpr.
+func (s *RunnerRPCServer) EvaluateExpr(args *EvaluateExprArgs, reply *EvaluateExprReply) error {
+	wantType, err := ctyjson.UnmarshalType(args.WantType)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal wanted type: %s", err)
+	}
+
+	expr, err := s.runner.lookupExpr(args.Expr)
+	if err != nil {
+		return err
+	}
+
+	val, err := s.runner.EvalExpr(expr, nil, wantType)
+	if err != nil {
+		return err
+	}
+
+	out, err := ctyjson.Marshal(val, wantType)
+	if err != nil {
+		return fmt.Errorf("failed to marshal evaluated value: %s", err)
+	}
+	reply.Value = out
+	return nil
+}
+
+// EvaluateBlockArgs is the net/rpc argument type for RunnerRPCServer.EvaluateBlock.
+type EvaluateBlockArgs struct {
+	Block  BlockRef
+	Schema []byte // configschema.Block encoded as JSON
+}
+
+// EvaluateBlockReply is the net/rpc reply type for RunnerRPCServer.EvaluateBlock.
+type EvaluateBlockReply struct {
+	Value []byte // cty.Value encoded with ctyjson.Marshal, typed by the schema's ImpliedType
+}
+
+// EvaluateBlock re-resolves the block identified by args.Block against the
+// server's own parsed files and evaluates it via the same
+// expandDynamicBlocks -> isEvaluableBlock -> EvaluateBlock pipeline
+// Runner.EvaluateBlock uses in-process, so a `dynamic {}`-generated block is
+// just as visible to a remote rule plugin as it is to an in-process rule.
+// We can't call Runner.EvaluateBlock itself, since it converts the result
+// into a caller-supplied Go value rather than returning the raw cty.Value
+// the RPC client wants.
+func (s *RunnerRPCServer) EvaluateBlock(args *EvaluateBlockArgs, reply *EvaluateBlockReply) error {
+	var schema configschema.Block
+	if err := json.Unmarshal(args.Schema, &schema); err != nil {
+		return fmt.Errorf("failed to unmarshal block schema: %s", err)
+	}
+
+	block, err := s.runner.lookupBlock(args.Block, &schema)
+	if err != nil {
+		return err
+	}
+
+	body, err := s.runner.expandDynamicBlocks(block.Body, &schema)
+	if err != nil {
+		return err
+	}
+
+	evaluable, err := isEvaluableBlock(body, &schema)
+	if err != nil {
+		return err
+	}
+	if !evaluable {
+		return &Error{
+			Code:  UnevaluableError,
+			Level: WarningLevel,
+			Message: fmt.Sprintf("Unevaluable block found in %s:%d", args.Block.Filename, args.Block.Range.Start.Line),
+		}
+	}
+
+	val, _, diags := s.runner.ctx.EvaluateBlock(body, &schema, nil, terraform.EvalDataForNoInstanceKey)
+	if diags.HasErrors() {
+		return diags.Err()
+	}
+
+	out, err := ctyjson.Marshal(val, schema.ImpliedType())
+	if err != nil {
+		return fmt.Errorf("failed to marshal evaluated block: %s", err)
+	}
+	reply.Value = out
+	return nil
+}
+
+// WalkResourceAttributeArgs is the net/rpc argument type for
+// RunnerRPCServer.WalkResourceAttribute.
+type WalkResourceAttributeArgs struct {
+	ResourceType  string
+	AttributeName string
+}
+
+// AttributeValue pairs an evaluated value with the source range it came
+// from, so a remote plugin can still report issues against the right line.
+type AttributeValue struct {
+	Range hcl.Range
+	Value []byte // cty.Value encoded with ctyjson.Marshal (always cty.DynamicPseudoType)
+}
+
+// WalkResourceAttributeReply is the net/rpc reply type for
+// RunnerRPCServer.WalkResourceAttribute.
+type WalkResourceAttributeReply struct {
+	Values []AttributeValue
+}
+
+// WalkResourceAttribute evaluates the named attribute on every resource of
+// the given type and returns each resource's value alongside its source
+// range, so a remote plugin can implement a rule without ever holding an
+// hcl.Expression itself. The attribute is evaluated once per resource
+// block; instances produced by count/for_each are not expanded.
+func (s *RunnerRPCServer) WalkResourceAttribute(args *WalkResourceAttributeArgs, reply *WalkResourceAttributeReply) error {
+	return s.runner.WalkResourceAttributes(args.ResourceType, args.AttributeName, func(attr *hcl.Attribute) error {
+		val, err := s.runner.EvalExpr(attr.Expr, nil, cty.DynamicPseudoType)
+		if err != nil {
+			return err
+		}
+		out, err := ctyjson.Marshal(val, cty.DynamicPseudoType)
+		if err != nil {
+			return err
+		}
+		reply.Values = append(reply.Values, AttributeValue{Range: attr.Expr.Range(), Value: out})
+		return nil
+	})
+}
+
+// exprRangeWalker is an hclsyntax.Walker that records the first expression
+// it finds occupying the target range.
+type exprRangeWalker struct {
+	target hcl.Range
+	found  hclsyntax.Expression
+}
+
+func (w *exprRangeWalker) Enter(node hclsyntax.Node) hcl.Diagnostics {
+	if expr, ok := node.(hclsyntax.Expression); ok && w.found == nil && expr.Range() == w.target {
+		w.found = expr
+	}
+	return nil
+}
+
+func (w *exprRangeWalker) Exit(node hclsyntax.Node) hcl.Diagnostics {
+	return nil
+}
+
+// lookupExpr walks the already-parsed file named by ref to find the
+// expression occupying exactly that range.
+func (r *Runner) lookupExpr(ref ExprRef) (hcl.Expression, error) {
+	file, exists := r.files[ref.Filename]
+	if !exists {
+		return nil, fmt.Errorf("file not loaded: %s", ref.Filename)
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("file is not native HCL syntax: %s", ref.Filename)
+	}
+
+	walker := &exprRangeWalker{target: ref.Range}
+	hclsyntax.Walk(body, walker)
+
+	if walker.found == nil {
+		return nil, fmt.Errorf("no expression found at %s:%s", ref.Filename, ref.Range)
+	}
+	return walker.found, nil
+}
+
+// lookupBlock walks the top-level blocks (matched against schema) of the
+// already-parsed file named by ref to find the one whose DefRange is
+// exactly that range.
+func (r *Runner) lookupBlock(ref BlockRef, schema *configschema.Block) (*hcl.Block, error) {
+	file, exists := r.files[ref.Filename]
+	if !exists {
+		return nil, fmt.Errorf("file not loaded: %s", ref.Filename)
+	}
+
+	content, _, diags := file.Body.PartialContent(terraform.SchemaHCLSpec(schema))
+	if diags.HasErrors() {
+		return nil, diags.Err()
+	}
+
+	for _, block := range content.Blocks {
+		if block.DefRange == ref.Range {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("no block found at %s:%s", ref.Filename, ref.Range)
+}
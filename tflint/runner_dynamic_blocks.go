@@ -0,0 +1,74 @@
+package tflint
+
+import (
+	"log"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/dynblock"
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// expandDynamicBlocks wraps body with dynblock.Expand so that any `dynamic`
+// blocks it declares are resolved into their generated concrete blocks
+// before the caller inspects or evaluates it. Without this, a rule walking
+// a block's schema would never see the blocks a `dynamic "ingress" { ... }`
+// construct generates, since HCL only expands them lazily on access.
+//
+// dynblock.ExpandVariables tells us which references the dynamic blocks in
+// body actually depend on (their for_each/labels/iterator expressions)
+// without needing a full EvalContext up front. If any of those references
+// can't be resolved here, we still expand with what we do have: sibling
+// dynamic blocks that don't depend on the unresolved reference remain
+// visible, while content reads that do need it surface the usual
+// unevaluable-block diagnostics further down instead of disappearing
+// silently (a raw, un-expanded body would otherwise never match a `dynamic
+// "foo" {...}` construct against a requested "foo" block type).
+func (r *Runner) expandDynamicBlocks(body hcl.Body, schema *configschema.Block) (hcl.Body, error) {
+	traversals := dynblock.ExpandVariables(body, terraform.SchemaHCLSpec(schema))
+
+	evaluable := make([]*addrs.Reference, 0, len(traversals))
+	unevaluable := false
+	for _, traversal := range traversals {
+		ref, diags := addrs.ParseRef(traversal)
+		if diags.HasErrors() {
+			unevaluable = true
+			continue
+		}
+		if isEvaluableRef(ref) {
+			evaluable = append(evaluable, ref)
+		} else {
+			unevaluable = true
+		}
+	}
+
+	evalCtx, err := r.hclEvalContext(evaluable)
+	if err != nil {
+		return nil, err
+	}
+
+	if unevaluable {
+		log.Printf("[WARN] Not all dynamic block dependencies could be resolved; falling back to partial expansion")
+	}
+
+	return dynblock.Expand(body, evalCtx), nil
+}
+
+// WalkExpandedBlocks expands any `dynamic` blocks in body and returns the
+// resulting concrete blocks of the block types schema declares, as
+// dynblock builds them: a generated block carries the DefRange of the
+// `dynamic` block that produced it.
+func (r *Runner) WalkExpandedBlocks(body hcl.Body, schema *configschema.Block) (hcl.Blocks, error) {
+	expanded, err := r.expandDynamicBlocks(body, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	content, _, diags := expanded.PartialContent(terraform.SchemaHCLSpec(schema))
+	if diags.HasErrors() {
+		return nil, diags.Err()
+	}
+
+	return content.Blocks, nil
+}
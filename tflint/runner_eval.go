@@ -142,8 +142,26 @@ func (r *Runner) EvalExpr(expr hcl.Expression, ret interface{}, wantType cty.Typ
 }
 
 // EvaluateBlock is a wrapper of terraform.BultinEvalContext.EvaluateBlock and gocty.FromCtyValue
+// Before evaluating, the block's body is expanded with dynblock.Expand so that any `dynamic`
+// blocks it contains are resolved into their generated concrete blocks first.
 func (r *Runner) EvaluateBlock(block *hcl.Block, schema *configschema.Block, ret interface{}) error {
-	evaluable, err := isEvaluableBlock(block.Body, schema)
+	body, err := r.expandDynamicBlocks(block.Body, schema)
+	if err != nil {
+		err := &Error{
+			Code:  EvaluationError,
+			Level: ErrorLevel,
+			Message: fmt.Sprintf(
+				"Failed to expand dynamic blocks in %s:%d",
+				block.DefRange.Filename,
+				block.DefRange.Start.Line,
+			),
+			Cause: err,
+		}
+		log.Printf("[ERROR] %s", err)
+		return err
+	}
+
+	evaluable, err := isEvaluableBlock(body, schema)
 	if err != nil {
 		err := &Error{
 			Code:  EvaluationError,
@@ -173,7 +191,7 @@ func (r *Runner) EvaluateBlock(block *hcl.Block, schema *configschema.Block, ret
 		return err
 	}
 
-	val, _, diags := r.ctx.EvaluateBlock(block.Body, schema, nil, terraform.EvalDataForNoInstanceKey)
+	val, _, diags := r.ctx.EvaluateBlock(body, schema, nil, terraform.EvalDataForNoInstanceKey)
 	if diags.HasErrors() {
 		err := &Error{
 			Code:  EvaluationError,
@@ -310,6 +328,19 @@ func isEvaluableBlock(body hcl.Body, schema *configschema.Block) (bool, error) {
 	return true, nil
 }
 
+// hclEvalContext builds an *hcl.EvalContext exposing the given references,
+// suitable for passing to HCL extensions (such as dynblock) that need to
+// evaluate expressions outside of terraform.BuiltinEvalContext's own
+// EvaluateExpr/EvaluateBlock entry points.
+func (r *Runner) hclEvalContext(refs []*addrs.Reference) (*hcl.EvalContext, error) {
+	scope := r.ctx.EvaluationScope(nil, terraform.EvalDataForNoInstanceKey)
+	evalCtx, diags := scope.EvalContext(refs)
+	if diags.HasErrors() {
+		return nil, diags.Err()
+	}
+	return evalCtx, nil
+}
+
 func isEvaluableRef(ref *addrs.Reference) bool {
 	switch ref.Subject.(type) {
 	case addrs.InputVariable:
@@ -318,6 +349,16 @@ func isEvaluableRef(ref *addrs.Reference) bool {
 		return true
 	case addrs.PathAttr:
 		return true
+	case addrs.LocalValue:
+		return true
+	case addrs.CountAttr:
+		return true
+	case addrs.ForEachAttr:
+		return true
+	case addrs.ModuleCallInstance:
+		return true
+	case addrs.ModuleCallOutput:
+		return true
 	default:
 		return false
 	}
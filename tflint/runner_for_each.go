@@ -0,0 +1,16 @@
+package tflint
+
+import (
+	"github.com/zclconf/go-cty/cty"
+)
+
+// validForEachType reports whether a for_each argument's evaluated value is
+// something Terraform itself would accept: a map, an object, or a set of
+// strings. cty.Value.CanIterateElements alone isn't enough for this check,
+// since it's also true for lists, tuples, and sets of non-string element
+// types, whose keys would panic a caller that assumes every for_each key is
+// a string (e.g. via key.AsString()).
+func validForEachType(forEach cty.Value) bool {
+	ty := forEach.Type()
+	return ty.IsMapType() || ty.IsObjectType() || (ty.IsSetType() && ty.ElementType() == cty.String)
+}
@@ -0,0 +1,55 @@
+package tflint
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func Test_validForEachType(t *testing.T) {
+	cases := []struct {
+		name string
+		val  cty.Value
+		want bool
+	}{
+		{
+			name: "map of strings",
+			val:  cty.MapVal(map[string]cty.Value{"a": cty.StringVal("x")}),
+			want: true,
+		},
+		{
+			name: "object",
+			val:  cty.ObjectVal(map[string]cty.Value{"a": cty.StringVal("x"), "b": cty.NumberIntVal(1)}),
+			want: true,
+		},
+		{
+			name: "set of strings",
+			val:  cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			want: true,
+		},
+		{
+			name: "set of numbers",
+			val:  cty.SetVal([]cty.Value{cty.NumberIntVal(1), cty.NumberIntVal(2)}),
+			want: false,
+		},
+		{
+			name: "list of strings",
+			val:  cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			want: false,
+		},
+		{
+			name: "tuple",
+			val:  cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.NumberIntVal(1)}),
+			want: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := validForEachType(tc.val)
+			if got != tc.want {
+				t.Fatalf("validForEachType(%#v) = %v, want %v", tc.val, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package tflint
+
+import (
+	"fmt"
+	"log"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/instances"
+	"github.com/hashicorp/terraform/lang"
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// WalkResourceExpressions calls walker once for every instance a resource's
+// `count` or `for_each` argument produces, passing the repetition data
+// (count.index, each.key, each.value) that applies to that instance. A
+// walker evaluates expr for its instance via EvalExprWithRepetitionData,
+// which is what actually binds count.index/each.key/each.value to the
+// right value — repData on its own is just data until something threads it
+// through an evaluation.
+//
+// Resources without `count` or `for_each` are walked exactly once with
+// empty repetition data, matching terraform.EvalDataForNoInstanceKey.
+func (r *Runner) WalkResourceExpressions(resource *configs.Resource, expr hcl.Expression, walker func(expr hcl.Expression, repData instances.RepetitionData) error) error {
+	switch {
+	case resource.Count != nil:
+		return r.walkCountInstances(resource, expr, walker)
+	case resource.ForEach != nil:
+		return r.walkForEachInstances(resource, expr, walker)
+	default:
+		return walker(expr, terraform.EvalDataForNoInstanceKey)
+	}
+}
+
+func (r *Runner) walkCountInstances(resource *configs.Resource, expr hcl.Expression, walker func(expr hcl.Expression, repData instances.RepetitionData) error) error {
+	var count int
+	if err := r.EvaluateExpr(resource.Count, &count); err != nil {
+		// Unevaluable/unknown count; fall back to the existing
+		// unevaluable-expression warning instead of guessing at instances
+		// that may never exist.
+		return err
+	}
+
+	for i := 0; i < count; i++ {
+		repData := terraform.EvalDataForInstanceKey(addrs.IntKey(i), nil)
+		if err := walker(expr, repData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) walkForEachInstances(resource *configs.Resource, expr hcl.Expression, walker func(expr hcl.Expression, repData instances.RepetitionData) error) error {
+	forEach, err := r.EvalExpr(resource.ForEach, nil, cty.DynamicPseudoType)
+	if err != nil {
+		if evalErr, ok := err.(*Error); ok && evalErr.Level == WarningLevel {
+			log.Printf("[WARN] Unknown for_each keyset on %s; skipping per-instance evaluation", resource.Addr())
+		}
+		return err
+	}
+
+	if !validForEachType(forEach) {
+		return &Error{
+			Code:  EvaluationError,
+			Level: ErrorLevel,
+			Message: fmt.Sprintf(
+				"Invalid for_each argument in %s:%d; for_each must be a map or set of strings",
+				resource.ForEach.Range().Filename,
+				resource.ForEach.Range().Start.Line,
+			),
+		}
+	}
+
+	forEachMap := map[string]cty.Value{}
+	it := forEach.ElementIterator()
+	for it.Next() {
+		key, value := it.Element()
+		forEachMap[key.AsString()] = value
+	}
+
+	it = forEach.ElementIterator()
+	for it.Next() {
+		key, _ := it.Element()
+		repData := terraform.EvalDataForInstanceKey(addrs.StringKey(key.AsString()), forEachMap)
+		if err := walker(expr, repData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EvalExprWithRepetitionData is like EvalExpr, but evaluates expr against a
+// per-instance scope built from repData instead of the Runner's shared
+// no-instance-key scope, so that count.index/each.key/each.value resolve to
+// the value for that specific instance. This is the entry point
+// WalkResourceExpressions's callers use to actually consume the repetition
+// data it hands them.
+func (r *Runner) EvalExprWithRepetitionData(expr hcl.Expression, wantType cty.Type, repData instances.RepetitionData) (cty.Value, error) {
+	evaluable, err := isEvaluableExpr(expr)
+	if err != nil {
+		err := &Error{
+			Code:  EvaluationError,
+			Level: ErrorLevel,
+			Message: fmt.Sprintf(
+				"Failed to parse an expression in %s:%d",
+				expr.Range().Filename,
+				expr.Range().Start.Line,
+			),
+			Cause: err,
+		}
+		log.Printf("[ERROR] %s", err)
+		return cty.NullVal(cty.NilType), err
+	}
+
+	if !evaluable {
+		err := &Error{
+			Code:  UnevaluableError,
+			Level: WarningLevel,
+			Message: fmt.Sprintf(
+				"Unevaluable expression found in %s:%d",
+				expr.Range().Filename,
+				expr.Range().Start.Line,
+			),
+		}
+		log.Printf("[WARN] %s; TFLint ignores an unevaluable expression.", err)
+		return cty.NullVal(cty.NilType), err
+	}
+
+	refs, diags := lang.ReferencesInExpr(expr)
+	if diags.HasErrors() {
+		return cty.NullVal(cty.NilType), diags.Err()
+	}
+
+	scope := r.ctx.EvaluationScope(nil, repData)
+	evalCtx, diags := scope.EvalContext(refs)
+	if diags.HasErrors() {
+		err := &Error{
+			Code:  EvaluationError,
+			Level: ErrorLevel,
+			Message: fmt.Sprintf(
+				"Failed to build a per-instance evaluation scope for %s:%d",
+				expr.Range().Filename,
+				expr.Range().Start.Line,
+			),
+			Cause: diags.Err(),
+		}
+		log.Printf("[ERROR] %s", err)
+		return cty.NullVal(cty.NilType), err
+	}
+
+	val, diags := expr.Value(evalCtx)
+	if diags.HasErrors() {
+		err := &Error{
+			Code:  EvaluationError,
+			Level: ErrorLevel,
+			Message: fmt.Sprintf(
+				"Failed to eval an expression in %s:%d",
+				expr.Range().Filename,
+				expr.Range().Start.Line,
+			),
+			Cause: diags.Err(),
+		}
+		log.Printf("[ERROR] %s", err)
+		return cty.NullVal(cty.NilType), err
+	}
+
+	if wantType != (cty.Type{}) {
+		converted, err := convert.Convert(val, wantType)
+		if err != nil {
+			err := &Error{
+				Code:  TypeConversionError,
+				Level: ErrorLevel,
+				Message: fmt.Sprintf(
+					"Invalid type expression in %s:%d",
+					expr.Range().Filename,
+					expr.Range().Start.Line,
+				),
+				Cause: err,
+			}
+			log.Printf("[ERROR] %s", err)
+			return cty.NullVal(cty.NilType), err
+		}
+		val = converted
+	}
+
+	err = cty.Walk(val, func(path cty.Path, v cty.Value) (bool, error) {
+		if !v.IsKnown() {
+			err := &Error{
+				Code:  UnknownValueError,
+				Level: WarningLevel,
+				Message: fmt.Sprintf(
+					"Unknown value found in %s:%d; Please use environment variables or tfvars to set the value",
+					expr.Range().Filename,
+					expr.Range().Start.Line,
+				),
+			}
+			log.Printf("[WARN] %s; TFLint ignores an expression includes an unknown value.", err)
+			return false, err
+		}
+
+		if v.IsNull() {
+			err := &Error{
+				Code:  NullValueError,
+				Level: WarningLevel,
+				Message: fmt.Sprintf(
+					"Null value found in %s:%d",
+					expr.Range().Filename,
+					expr.Range().Start.Line,
+				),
+			}
+			log.Printf("[WARN] %s; TFLint ignores an expression includes an null value.", err)
+			return false, err
+		}
+
+		return true, nil
+	})
+
+	if err != nil {
+		return cty.NullVal(cty.NilType), err
+	}
+
+	return val, nil
+}
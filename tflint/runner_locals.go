@@ -0,0 +1,168 @@
+package tflint
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/lang"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Init runs the evaluation passes every Runner needs before any rule can
+// call EvalExpr/EvaluateBlock: registering locals (setLocalValues, below)
+// and resolving module call outputs (setModuleValues, runner_modules.go).
+// Whatever constructs a Runner — the root Runner built for the module under
+// lint, and NewChildRunner for a `module` call it references — must call
+// Init immediately afterwards, or local.*/module.*.* references on that
+// Runner will stay unevaluable.
+func (r *Runner) Init() error {
+	if err := r.setLocalValues(); err != nil {
+		return err
+	}
+	return r.setModuleValues()
+}
+
+// setLocalValues evaluates every `locals` block declared in the module and
+// registers the results on the Runner's evaluation context so that
+// `local.*` references become evaluable like any other reference.
+//
+// Locals are resolved in dependency order because a local's expression may
+// reference another local (or an input variable); a cycle between locals is
+// reported as an error instead of recursing forever.
+func (r *Runner) setLocalValues() error {
+	locals := r.TFConfig.Module.Locals
+
+	deps, err := localDependencies(locals)
+	if err != nil {
+		return err
+	}
+
+	order, cycle := localDependencyOrder(deps)
+	if cycle != "" {
+		local := locals[cycle]
+		return &Error{
+			Code:  EvaluationError,
+			Level: ErrorLevel,
+			Message: fmt.Sprintf(
+				"circular reference to local.%s detected in %s:%d",
+				cycle,
+				local.DeclRange.Filename,
+				local.DeclRange.Start.Line,
+			),
+		}
+	}
+
+	for _, name := range order {
+		local, exists := locals[name]
+		if !exists {
+			// Referenced by another local but not declared itself; the
+			// ordinary evaluator will surface a "reference to undeclared
+			// local value" error once something actually reads it.
+			continue
+		}
+		if err := r.setLocalValue(local); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) setLocalValue(local *configs.Local) error {
+	val, err := r.EvalExpr(local.Expr, nil, cty.DynamicPseudoType)
+	if err != nil {
+		if evalErr, ok := err.(*Error); ok && evalErr.Level == WarningLevel {
+			// Unevaluable/unknown locals are allowed to pass through as
+			// unknown so that dependents are downgraded the same way.
+			val = cty.DynamicVal
+		} else {
+			return &Error{
+				Code:  EvaluationError,
+				Level: ErrorLevel,
+				Message: fmt.Sprintf(
+					"Failed to eval local.%s declared in %s:%d",
+					local.Name,
+					local.DeclRange.Filename,
+					local.DeclRange.Start.Line,
+				),
+				Cause: err,
+			}
+		}
+	}
+
+	r.ctx.SetLocalValue(addrs.LocalValue{Name: local.Name}, val)
+	log.Printf("[DEBUG] Registered local.%s", local.Name)
+	return nil
+}
+
+// localDependencies builds the dependency graph setLocalValues resolves:
+// one key per declared local, regardless of whether it references any
+// other local, mapped to the names of the other locals its expression
+// references. Every declared local must get a key here even with a nil/empty
+// value, or localDependencyOrder will never visit it.
+func localDependencies(locals map[string]*configs.Local) (map[string][]string, error) {
+	deps := make(map[string][]string, len(locals))
+	for name := range locals {
+		deps[name] = nil
+	}
+	for name, local := range locals {
+		refs, diags := lang.ReferencesInExpr(local.Expr)
+		if diags.HasErrors() {
+			return nil, diags.Err()
+		}
+		for _, ref := range refs {
+			if dep, ok := ref.Subject.(addrs.LocalValue); ok {
+				deps[name] = append(deps[name], dep.Name)
+			}
+		}
+	}
+	return deps, nil
+}
+
+// localDependencyOrder topologically sorts the names in deps so that every
+// local appears after the locals it depends on. A name referenced as a
+// dependency but absent from deps (a reference to an undeclared local, or
+// to something that isn't a local at all) is treated as a leaf with no
+// dependencies of its own, rather than tripping the cycle detector.
+//
+// If deps contains a cycle, the second return value is the name of a local
+// on that cycle and the order is nil.
+func localDependencyOrder(deps map[string][]string) (order []string, cycle string) {
+	resolved := map[string]bool{}
+	resolving := map[string]bool{}
+
+	var visit func(name string) string
+	visit = func(name string) string {
+		if resolved[name] {
+			return ""
+		}
+		if resolving[name] {
+			return name
+		}
+		resolving[name] = true
+		for _, dep := range deps[name] {
+			if c := visit(dep); c != "" {
+				return c
+			}
+		}
+		resolving[name] = false
+		resolved[name] = true
+		order = append(order, name)
+		return ""
+	}
+
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if c := visit(name); c != "" {
+			return nil, c
+		}
+	}
+	return order, ""
+}
@@ -0,0 +1,103 @@
+package tflint
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func Test_localDependencies_includesLocalsWithoutCrossReferences(t *testing.T) {
+	locals := map[string]*configs.Local{
+		"name_prefix": {Name: "name_prefix", Expr: hcl.StaticExpr(cty.StringVal("foo"), hcl.Range{})},
+		"env":         {Name: "env", Expr: hcl.StaticExpr(cty.StringVal("prod"), hcl.Range{})},
+	}
+
+	deps, err := localDependencies(locals)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	order, cycle := localDependencyOrder(deps)
+	if cycle != "" {
+		t.Fatalf("unexpected cycle: %s", cycle)
+	}
+	if len(order) != 2 {
+		t.Fatalf("expected every declared local to be visited even without cross-references, got order %v", order)
+	}
+}
+
+func Test_localDependencies_ordersByReference(t *testing.T) {
+	locals := map[string]*configs.Local{
+		"a": {Name: "a", Expr: &hclsyntax.ScopeTraversalExpr{
+			Traversal: hcl.Traversal{
+				hcl.TraverseRoot{Name: "local"},
+				hcl.TraverseAttr{Name: "b"},
+			},
+		}},
+		"b": {Name: "b", Expr: hcl.StaticExpr(cty.StringVal("leaf"), hcl.Range{})},
+	}
+
+	deps, err := localDependencies(locals)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(deps["a"]) != 1 || deps["a"][0] != "b" {
+		t.Fatalf("expected local.a to depend on local.b, got %v", deps["a"])
+	}
+
+	order, cycle := localDependencyOrder(deps)
+	if cycle != "" {
+		t.Fatalf("unexpected cycle: %s", cycle)
+	}
+	pos := map[string]int{}
+	for i, name := range order {
+		pos[name] = i
+	}
+	if pos["b"] > pos["a"] {
+		t.Fatalf("expected b before a, got %v", order)
+	}
+}
+
+func Test_localDependencyOrder_ordersDependenciesFirst(t *testing.T) {
+	order, cycle := localDependencyOrder(map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": nil,
+	})
+	if cycle != "" {
+		t.Fatalf("unexpected cycle: %s", cycle)
+	}
+
+	pos := map[string]int{}
+	for i, name := range order {
+		pos[name] = i
+	}
+	if pos["c"] > pos["b"] || pos["b"] > pos["a"] {
+		t.Fatalf("expected order c, b, a (dependencies first); got %v", order)
+	}
+}
+
+func Test_localDependencyOrder_detectsCycle(t *testing.T) {
+	_, cycle := localDependencyOrder(map[string][]string{
+		"a": {"b"},
+		"b": {"a"},
+	})
+	if cycle == "" {
+		t.Fatal("expected a cycle to be detected")
+	}
+}
+
+func Test_localDependencyOrder_undeclaredDependencyIsLeaf(t *testing.T) {
+	order, cycle := localDependencyOrder(map[string][]string{
+		"a": {"undeclared"},
+	})
+	if cycle != "" {
+		t.Fatalf("unexpected cycle: %s", cycle)
+	}
+	if len(order) != 2 || order[0] != "undeclared" || order[1] != "a" {
+		t.Fatalf("expected [undeclared, a], got %v", order)
+	}
+}
@@ -0,0 +1,282 @@
+package tflint
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform/configs"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// moduleCache memoizes an evaluated module call's output object keyed by
+// its source address, pinned version, and argument values, so that a
+// module referenced from several `module` blocks with the same arguments
+// (a common pattern for shared infrastructure modules) is only walked and
+// evaluated once. Argument values are part of the key: two calls sharing a
+// source but passing different variables must not see each other's
+// outputs.
+//
+// The cached value is always the module's raw, pre-repetition output
+// object; applyModuleCallRepetition runs on every lookup, cache hit or
+// miss, so that two `module` blocks sharing source/version/args but
+// differing in count/for_each each get their own correctly shaped
+// tuple/object instead of colliding on the cache.
+//
+// moduleCacheMu guards moduleCache, which is shared by every Runner in the
+// process.
+var (
+	moduleCacheMu sync.Mutex
+	moduleCache   = map[string]cty.Value{}
+)
+
+// setModuleValues evaluates every `module` call in the root config and
+// registers the child module's outputs under `module.<name>` on the
+// Runner's evaluation context, so `module.<name>.<output>` becomes
+// evaluable like any other reference.
+func (r *Runner) setModuleValues() error {
+	for _, call := range r.TFConfig.Module.ModuleCalls {
+		if err := r.setModuleValue(call); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) setModuleValue(call *configs.ModuleCall) error {
+	childConfig := r.TFConfig.Children[call.Name]
+	if childConfig == nil {
+		// Not loaded, e.g. `terraform init` hasn't fetched this module yet.
+		// Leave the reference unevaluable rather than failing outright.
+		log.Printf("[WARN] module.%s is not loaded; module.%s.* references will be unevaluable", call.Name, call.Name)
+		return nil
+	}
+
+	args, err := r.evalModuleCallArguments(call)
+	if err != nil {
+		return err
+	}
+
+	cacheKey, err := moduleCacheKey(call, args)
+	if err != nil {
+		return err
+	}
+
+	moduleCacheMu.Lock()
+	outputVal, ok := moduleCache[cacheKey]
+	moduleCacheMu.Unlock()
+
+	if !ok {
+		child, err := NewChildRunner(childConfig, args)
+		if err != nil {
+			return &Error{
+				Code:  EvaluationError,
+				Level: ErrorLevel,
+				Message: fmt.Sprintf(
+					"Failed to evaluate module.%s declared in %s:%d",
+					call.Name,
+					call.DeclRange.Filename,
+					call.DeclRange.Start.Line,
+				),
+				Cause: err,
+			}
+		}
+
+		outputVal, err = child.evalModuleOutputs()
+		if err != nil {
+			return err
+		}
+
+		moduleCacheMu.Lock()
+		moduleCache[cacheKey] = outputVal
+		moduleCacheMu.Unlock()
+	}
+
+	instanceVal, err := r.applyModuleCallRepetition(call, outputVal)
+	if err != nil {
+		return err
+	}
+
+	r.ctx.SetModuleCallOutput(call.Name, instanceVal)
+	log.Printf("[DEBUG] Registered module.%s", call.Name)
+	return nil
+}
+
+// evalModuleCallArguments evaluates the module call's own arguments so they
+// can be bound as input variables on the child Runner.
+func (r *Runner) evalModuleCallArguments(call *configs.ModuleCall) (map[string]cty.Value, error) {
+	attrs, diags := call.Config.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags.Err()
+	}
+
+	args := make(map[string]cty.Value, len(attrs))
+	for name, attr := range attrs {
+		val, err := r.EvalExpr(attr.Expr, nil, cty.DynamicPseudoType)
+		if err != nil {
+			if evalErr, ok := err.(*Error); ok && evalErr.Level == WarningLevel {
+				val = cty.DynamicVal
+			} else {
+				return nil, err
+			}
+		}
+		args[name] = val
+	}
+	return args, nil
+}
+
+// applyModuleCallRepetition reshapes a module's output object into a tuple
+// (for `count`) or object (for `for_each`) of per-instance output objects,
+// matching how `module.<name>[*]`/`module.<name>["key"]` behave in
+// Terraform itself.
+func (r *Runner) applyModuleCallRepetition(call *configs.ModuleCall, outputVal cty.Value) (cty.Value, error) {
+	switch {
+	case call.Count != nil:
+		var count int
+		if err := r.EvaluateExpr(call.Count, &count); err != nil {
+			if evalErr, ok := err.(*Error); ok && evalErr.Level == WarningLevel {
+				return cty.DynamicVal, nil
+			}
+			return cty.NilVal, err
+		}
+		if count < 0 {
+			return cty.NilVal, &Error{
+				Code:  EvaluationError,
+				Level: ErrorLevel,
+				Message: fmt.Sprintf(
+					"Invalid count argument for module.%s in %s:%d; count must not be negative",
+					call.Name,
+					call.Count.Range().Filename,
+					call.Count.Range().Start.Line,
+				),
+			}
+		}
+		if count == 0 {
+			return cty.EmptyTupleVal, nil
+		}
+		instances := make([]cty.Value, count)
+		for i := range instances {
+			instances[i] = outputVal
+		}
+		return cty.TupleVal(instances), nil
+
+	case call.ForEach != nil:
+		forEach, err := r.EvalExpr(call.ForEach, nil, cty.DynamicPseudoType)
+		if err != nil {
+			if evalErr, ok := err.(*Error); ok && evalErr.Level == WarningLevel {
+				return cty.DynamicVal, nil
+			}
+			return cty.NilVal, err
+		}
+		if !validForEachType(forEach) {
+			return cty.NilVal, &Error{
+				Code:  EvaluationError,
+				Level: ErrorLevel,
+				Message: fmt.Sprintf(
+					"Invalid for_each argument for module.%s in %s:%d; for_each must be a map or set of strings",
+					call.Name,
+					call.ForEach.Range().Filename,
+					call.ForEach.Range().Start.Line,
+				),
+			}
+		}
+		instances := map[string]cty.Value{}
+		it := forEach.ElementIterator()
+		for it.Next() {
+			key, _ := it.Element()
+			instances[key.AsString()] = outputVal
+		}
+		if len(instances) == 0 {
+			return cty.EmptyObjectVal, nil
+		}
+		return cty.ObjectVal(instances), nil
+
+	default:
+		return outputVal, nil
+	}
+}
+
+// moduleCacheKey identifies a module call's evaluated outputs by source,
+// pinned version, and argument values, so calls that share a source but
+// pass different variables don't collide in moduleCache.
+func moduleCacheKey(call *configs.ModuleCall, args map[string]cty.Value) (string, error) {
+	key, err := moduleArgsCacheKey(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to build a cache key for module.%s: %s", call.Name, err)
+	}
+	return call.SourceAddr.String() + "@" + call.Version.Required.String() + key, nil
+}
+
+// moduleArgsCacheKey deterministically encodes args (sorted by name, each
+// value serialized with cty/json) as a cache key suffix.
+func moduleArgsCacheKey(args map[string]cty.Value) (string, error) {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	for _, name := range names {
+		encoded, err := ctyjson.Marshal(args[name], args[name].Type())
+		if err != nil {
+			return "", fmt.Errorf("failed to encode argument %s: %s", name, err)
+		}
+		key.WriteString("|")
+		key.WriteString(name)
+		key.WriteString("=")
+		key.Write(encoded)
+	}
+	return key.String(), nil
+}
+
+// NewChildRunner constructs a Runner for a child module being evaluated as
+// part of a parent `module` call, binding args as the child's input
+// variable values, then runs the same locals/module-loading passes the
+// root Runner runs so that nested `module.*`/`local.*` references keep
+// working at any depth.
+func NewChildRunner(config *configs.Config, args map[string]cty.Value) (*Runner, error) {
+	runner, err := NewRunnerWithVariables(config, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := runner.Init(); err != nil {
+		return nil, err
+	}
+	return runner, nil
+}
+
+// evalModuleOutputs evaluates every `output` block declared in the module
+// and returns them as a single cty object keyed by output name, suitable
+// for publishing under `module.<name>` on the caller's evaluation context.
+func (r *Runner) evalModuleOutputs() (cty.Value, error) {
+	outputs := map[string]cty.Value{}
+	for _, output := range r.TFConfig.Module.Outputs {
+		val, err := r.EvalExpr(output.Expr, nil, cty.DynamicPseudoType)
+		if err != nil {
+			if evalErr, ok := err.(*Error); ok && evalErr.Level == WarningLevel {
+				val = cty.DynamicVal
+			} else {
+				return cty.NilVal, &Error{
+					Code:  EvaluationError,
+					Level: ErrorLevel,
+					Message: fmt.Sprintf(
+						"Failed to eval output.%s declared in %s:%d",
+						output.Name,
+						output.DeclRange.Filename,
+						output.DeclRange.Start.Line,
+					),
+					Cause: err,
+				}
+			}
+		}
+		outputs[output.Name] = val
+	}
+	if len(outputs) == 0 {
+		return cty.EmptyObjectVal, nil
+	}
+	return cty.ObjectVal(outputs), nil
+}
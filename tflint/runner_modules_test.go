@@ -0,0 +1,52 @@
+package tflint
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func Test_moduleArgsCacheKey_differsByArgValue(t *testing.T) {
+	keyA, err := moduleArgsCacheKey(map[string]cty.Value{"name": cty.StringVal("a")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	keyB, err := moduleArgsCacheKey(map[string]cty.Value{"name": cty.StringVal("b")})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if keyA == keyB {
+		t.Fatalf("expected distinct cache keys for distinct arguments, got %q for both", keyA)
+	}
+}
+
+func Test_moduleArgsCacheKey_stableForEqualArgs(t *testing.T) {
+	args := map[string]cty.Value{
+		"name":  cty.StringVal("a"),
+		"count": cty.NumberIntVal(2),
+	}
+
+	keyA, err := moduleArgsCacheKey(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	keyB, err := moduleArgsCacheKey(args)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if keyA != keyB {
+		t.Fatalf("expected the same arguments to produce the same cache key, got %q and %q", keyA, keyB)
+	}
+}
+
+func Test_moduleArgsCacheKey_noArgs(t *testing.T) {
+	key, err := moduleArgsCacheKey(map[string]cty.Value{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if key != "" {
+		t.Fatalf("expected an empty suffix for no arguments, got %q", key)
+	}
+}
@@ -0,0 +1,35 @@
+package tflint
+
+import (
+	"fmt"
+
+	hcl "github.com/hashicorp/hcl/v2"
+)
+
+// WalkResourceAttributes calls walker once for every resource of the given
+// type that sets the named top-level attribute, passing the hcl.Attribute
+// so the caller can evaluate or inspect it directly.
+func (r *Runner) WalkResourceAttributes(resourceType, attributeName string, walker func(attr *hcl.Attribute) error) error {
+	for _, resource := range r.TFConfig.Module.ManagedResources {
+		if resource.Type != resourceType {
+			continue
+		}
+
+		body, _, diags := resource.Config.PartialContent(&hcl.BodySchema{
+			Attributes: []hcl.AttributeSchema{{Name: attributeName}},
+		})
+		if diags.HasErrors() {
+			return fmt.Errorf("failed to read %s.%s: %s", resource.Addr(), attributeName, diags.Err())
+		}
+
+		attr, exists := body.Attributes[attributeName]
+		if !exists {
+			continue
+		}
+
+		if err := walker(attr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package tflint
+
+import (
+	"fmt"
+	"log"
+
+	hcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// WalkVariableValidations evaluates every `validation` block declared on
+// input variables that have a bound value (from tfvars, environment
+// variables, or a default) and calls walker with the variable, the failed
+// validation, and the rendered error message whenever a condition evaluates
+// to false.
+//
+// Validations whose condition depends on an unknown value are skipped with
+// a warning rather than reported as failures, matching the way EvalExpr
+// already downgrades unknown values elsewhere.
+func (r *Runner) WalkVariableValidations(walker func(variable *configs.Variable, validation *configs.VariableValidation, errorMessage string) error) error {
+	for _, variable := range r.TFConfig.Module.Variables {
+		for _, validation := range variable.Validations {
+			if err := r.walkVariableValidation(variable, validation, walker); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Runner) walkVariableValidation(variable *configs.Variable, validation *configs.VariableValidation, walker func(variable *configs.Variable, validation *configs.VariableValidation, errorMessage string) error) error {
+	evalCtx, err := r.variableEvalContext(variable)
+	if err != nil {
+		return &Error{
+			Code:  EvaluationError,
+			Level: ErrorLevel,
+			Message: fmt.Sprintf(
+				"Failed to build an eval context for var.%s in %s:%d",
+				variable.Name,
+				validation.DeclRange.Filename,
+				validation.DeclRange.Start.Line,
+			),
+			Cause: err,
+		}
+	}
+
+	conditionVal, diags := validation.Condition.Value(evalCtx)
+	if diags.HasErrors() {
+		return &Error{
+			Code:  EvaluationError,
+			Level: ErrorLevel,
+			Message: fmt.Sprintf(
+				"Failed to eval a validation condition for var.%s in %s:%d",
+				variable.Name,
+				validation.DeclRange.Filename,
+				validation.DeclRange.Start.Line,
+			),
+			Cause: diags.Err(),
+		}
+	}
+
+	if !conditionVal.IsKnown() {
+		log.Printf("[WARN] Unknown value in validation condition for var.%s; skipping", variable.Name)
+		return nil
+	}
+
+	if conditionVal.IsNull() {
+		return nil
+	}
+
+	conditionVal, err = convert.Convert(conditionVal, cty.Bool)
+	if err != nil {
+		return &Error{
+			Code:  TypeConversionError,
+			Level: ErrorLevel,
+			Message: fmt.Sprintf(
+				"condition must be a bool for var.%s in %s:%d",
+				variable.Name,
+				validation.DeclRange.Filename,
+				validation.DeclRange.Start.Line,
+			),
+			Cause: err,
+		}
+	}
+
+	if conditionVal.True() {
+		return nil
+	}
+
+	errorMessage, err := r.variableValidationErrorMessage(validation, evalCtx)
+	if err != nil {
+		return err
+	}
+
+	return walker(variable, validation, errorMessage)
+}
+
+// variableValidationErrorMessage evaluates the validation's error_message,
+// which since Terraform 1.x may itself be an arbitrary expression rather
+// than a plain string literal.
+func (r *Runner) variableValidationErrorMessage(validation *configs.VariableValidation, evalCtx *hcl.EvalContext) (string, error) {
+	val, diags := validation.ErrorMessage.Value(evalCtx)
+	if diags.HasErrors() {
+		return "", &Error{
+			Code:  EvaluationError,
+			Level: ErrorLevel,
+			Message: fmt.Sprintf(
+				"Failed to eval an error_message in %s:%d",
+				validation.DeclRange.Filename,
+				validation.DeclRange.Start.Line,
+			),
+			Cause: diags.Err(),
+		}
+	}
+
+	var message string
+	if err := gocty.FromCtyValue(val, &message); err != nil {
+		return "", &Error{
+			Code:  TypeMismatchError,
+			Level: ErrorLevel,
+			Message: fmt.Sprintf(
+				"error_message must be a string in %s:%d",
+				validation.DeclRange.Filename,
+				validation.DeclRange.Start.Line,
+			),
+			Cause: err,
+		}
+	}
+	return message, nil
+}
+
+// variableEvalContext builds an *hcl.EvalContext scoped to just
+// `var.<name>`. A failure here (the variable has no value bound at all) is
+// a hard error for the caller to wrap; unknown values are let through and
+// handled afterwards by the conditionVal.IsKnown() check above.
+func (r *Runner) variableEvalContext(variable *configs.Variable) (*hcl.EvalContext, error) {
+	refs := []*addrs.Reference{
+		{Subject: addrs.InputVariable{Name: variable.Name}},
+	}
+	return r.hclEvalContext(refs)
+}